@@ -0,0 +1,97 @@
+package sqlstruct
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrMultipleRows is returned by ScanOne and CollectOne when the result set
+// contains more than one row.
+var ErrMultipleRows = errors.New("sqlstruct: multiple rows in result set")
+
+// RowsIterator extends Rows with the methods needed to walk an entire result
+// set, as required by ScanAll, ScanOne, Collect and CollectOne. It is
+// implemented by the sql.Rows type from the standard library.
+type RowsIterator interface {
+	Rows
+	Next() bool
+	Err() error
+}
+
+// ScanAll scans every row in rows into dest, which must be a pointer to a
+// slice of struct or pointer-to-struct elements (*[]T or *[]*T). Field
+// resolution follows the same rules as Scan.
+func ScanAll(dest interface{}, rows RowsIterator) error {
+	destv := reflect.ValueOf(dest)
+	if destv.Kind() != reflect.Ptr || destv.Elem().Kind() != reflect.Slice {
+		panic(fmt.Errorf("dest must be pointer to slice; got %T", dest))
+	}
+
+	slice := destv.Elem()
+	elemType := slice.Type().Elem()
+
+	ptrElem := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if ptrElem {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		panic(fmt.Errorf("dest must be pointer to slice of struct or pointer to struct; got %T", dest))
+	}
+
+	for rows.Next() {
+		v := reflect.New(structType)
+		if err := Scan(v.Interface(), rows); err != nil {
+			return err
+		}
+		if ptrElem {
+			slice.Set(reflect.Append(slice, v))
+		} else {
+			slice.Set(reflect.Append(slice, v.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// ScanOne scans exactly one row from rows into dest, which must be a pointer
+// to a struct. It returns sql.ErrNoRows if rows yields no rows, and
+// ErrMultipleRows if rows yields more than one.
+func ScanOne(dest interface{}, rows RowsIterator) error {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	if err := Scan(dest, rows); err != nil {
+		return err
+	}
+
+	if rows.Next() {
+		return ErrMultipleRows
+	}
+
+	return rows.Err()
+}
+
+// Collect scans every row in rows into a []T, following the same rules as
+// ScanAll.
+func Collect[T any](rows RowsIterator) ([]T, error) {
+	var result []T
+	if err := ScanAll(&result, rows); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectOne scans exactly one row from rows into a T, following the same
+// rules as ScanOne.
+func CollectOne[T any](rows RowsIterator) (T, error) {
+	var v T
+	err := ScanOne(&v, rows)
+	return v, err
+}
@@ -0,0 +1,113 @@
+package sqlstruct
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+// iterRows adapts a sequence of testRows into a RowsIterator by walking a
+// fixed list of single-row frames, as a real *sql.Rows would across Next calls.
+type iterRows struct {
+	frames []testRows
+	pos    int
+}
+
+func (r *iterRows) Next() bool {
+	if r.pos >= len(r.frames) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+func (r *iterRows) Err() error { return nil }
+
+func (r *iterRows) Scan(dest ...interface{}) error {
+	return r.frames[r.pos-1].Scan(dest...)
+}
+
+func (r *iterRows) Columns() ([]string, error) {
+	return r.frames[r.pos-1].Columns()
+}
+
+func newIterRows(rowsData ...[][2]interface{}) *iterRows {
+	var frames []testRows
+	for _, row := range rowsData {
+		var fr testRows
+		for _, cv := range row {
+			fr.addValue(cv[0].(string), cv[1])
+		}
+		frames = append(frames, fr)
+	}
+	return &iterRows{frames: frames}
+}
+
+func TestScanAll(t *testing.T) {
+	rows := newIterRows(
+		[][2]interface{}{{"field_a", "a1"}, {"field_d", "d1"}},
+		[][2]interface{}{{"field_a", "a2"}, {"field_d", "d2"}},
+	)
+
+	var out []testType
+	if err := ScanAll(&out, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := []testType{{FieldA: "a1", FieldD: "d1"}, {FieldA: "a2", FieldD: "d2"}}
+	if !reflect.DeepEqual(out, e) {
+		t.Errorf("expected %+v got %+v", e, out)
+	}
+}
+
+func TestScanOneNoRows(t *testing.T) {
+	rows := newIterRows()
+
+	var out testType
+	if err := ScanOne(&out, rows); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScanOneMultipleRows(t *testing.T) {
+	rows := newIterRows(
+		[][2]interface{}{{"field_a", "a1"}},
+		[][2]interface{}{{"field_a", "a2"}},
+	)
+
+	var out testType
+	if err := ScanOne(&out, rows); err != ErrMultipleRows {
+		t.Errorf("expected ErrMultipleRows, got %v", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	rows := newIterRows(
+		[][2]interface{}{{"field_a", "a1"}},
+		[][2]interface{}{{"field_a", "a2"}},
+	)
+
+	out, err := Collect[testType](rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := []testType{{FieldA: "a1"}, {FieldA: "a2"}}
+	if !reflect.DeepEqual(out, e) {
+		t.Errorf("expected %+v got %+v", e, out)
+	}
+}
+
+func TestCollectOne(t *testing.T) {
+	rows := newIterRows([][2]interface{}{{"field_a", "a1"}})
+
+	out, err := CollectOne[testType](rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := testType{FieldA: "a1"}
+	if out != e {
+		t.Errorf("expected %+v got %+v", e, out)
+	}
+}
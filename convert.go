@@ -0,0 +1,101 @@
+package sqlstruct
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+)
+
+// ConverterFactory produces a scan target to receive a driver value for a
+// single field, and an assign function that copies the scanned value out of
+// that target into the destination field once rows.Scan has populated it.
+// RegisterConverter associates one with a concrete field type.
+type ConverterFactory func() (scanTarget interface{}, assign func(field reflect.Value))
+
+// scannerType is the sql.Scanner interface, used to detect fields that know
+// how to scan themselves.
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+var converters map[reflect.Type]ConverterFactory
+var convertersLock sync.RWMutex
+
+func init() {
+	converters = make(map[reflect.Type]ConverterFactory)
+}
+
+// RegisterConverter associates factory with typ, so that Scan uses it to
+// build a scan target for fields of that exact type instead of the built-in
+// bool/int/float/string nullable wrappers or a direct assignment. This lets
+// callers plug in types this package doesn't know about, such as
+// sql.NullTime, pgtype.Numeric, or their own sql.Scanner wrappers. Fields
+// whose type already implements sql.Scanner don't need one registered: Scan
+// passes their address through untouched.
+func RegisterConverter(typ reflect.Type, factory ConverterFactory) {
+	convertersLock.Lock()
+	converters[typ] = factory
+	convertersLock.Unlock()
+}
+
+func getConverter(typ reflect.Type) (ConverterFactory, bool) {
+	convertersLock.RLock()
+	factory, ok := converters[typ]
+	convertersLock.RUnlock()
+	return factory, ok
+}
+
+// The four converters below back Scan's built-in nullable handling for
+// bool/int/float/string kinds. They are dispatched by reflect.Kind rather
+// than registered by exact type, since they need to apply across every
+// concrete type sharing that kind.
+
+func nullBoolConverter() (interface{}, func(reflect.Value)) {
+	nb := &sql.NullBool{}
+	return nb, func(field reflect.Value) {
+		if nb.Valid {
+			field.SetBool(nb.Bool)
+		} else {
+			field.SetBool(false)
+		}
+	}
+}
+
+func nullInt64Converter() (interface{}, func(reflect.Value)) {
+	ni := &sql.NullInt64{}
+	return ni, func(field reflect.Value) {
+		var value int64
+		if ni.Valid {
+			value = ni.Int64
+		}
+		// This backs both signed and unsigned integer kinds (see the Kind
+		// switch in planFieldScan), so the setter must match field's kind;
+		// SetInt on a Uint-kinded Value panics.
+		switch field.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetUint(uint64(value))
+		default:
+			field.SetInt(value)
+		}
+	}
+}
+
+func nullFloat64Converter() (interface{}, func(reflect.Value)) {
+	nf := &sql.NullFloat64{}
+	return nf, func(field reflect.Value) {
+		if nf.Valid {
+			field.SetFloat(nf.Float64)
+		} else {
+			field.SetFloat(0)
+		}
+	}
+}
+
+func nullStringConverter() (interface{}, func(reflect.Value)) {
+	ns := &sql.NullString{}
+	return ns, func(field reflect.Value) {
+		if ns.Valid {
+			field.SetString(ns.String)
+		} else {
+			field.SetString("")
+		}
+	}
+}
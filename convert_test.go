@@ -0,0 +1,125 @@
+package sqlstruct
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// upperString is a custom sql.Scanner that appends "!" to whatever it scans,
+// to verify Scan leaves Scanner-implementing fields alone.
+type upperString string
+
+func (u *upperString) Scan(value interface{}) error {
+	s, _ := value.(string)
+	*u = upperString(s + "!")
+	return nil
+}
+
+func (u upperString) Value() (driver.Value, error) {
+	return string(u), nil
+}
+
+type convertTestType struct {
+	CreatedAt time.Time `sql:"created_at"`
+	Label     upperString
+	Note      string `sql:"note,notnull"`
+}
+
+func init() {
+	RegisterConverter(reflect.TypeOf(time.Time{}), func() (interface{}, func(reflect.Value)) {
+		var nt sql.NullTime
+		return &nt, func(field reflect.Value) {
+			if nt.Valid {
+				field.Set(reflect.ValueOf(nt.Time))
+			}
+		}
+	})
+}
+
+func TestRegisteredConverter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rows := testRows{}
+	rows.addValue("created_at", now)
+	rows.addValue("label", "x")
+	rows.addValue("note", "n")
+
+	var v convertTestType
+	if err := Scan(&v, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !v.CreatedAt.Equal(now) {
+		t.Errorf("expected CreatedAt %v got %v", now, v.CreatedAt)
+	}
+}
+
+func TestScannerFieldPassesThrough(t *testing.T) {
+	rows := testRows{}
+	rows.addValue("created_at", time.Now())
+	rows.addValue("label", "x")
+	rows.addValue("note", "n")
+
+	var v convertTestType
+	if err := Scan(&v, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if v.Label != "x!" {
+		t.Errorf("expected Label %q got %q", "x!", v.Label)
+	}
+}
+
+func TestNotNullSkipsWrapping(t *testing.T) {
+	rows := testRows{}
+	rows.addValue("created_at", time.Now())
+	rows.addValue("label", "x")
+	rows.addValue("note", "n")
+
+	var v convertTestType
+	if err := Scan(&v, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "n"
+	if v.Note != e {
+		t.Errorf("expected Note %q got %q", e, v.Note)
+	}
+
+	// A notnull field is scanned straight into its own address, with no
+	// nullable wrapper to copy out of afterwards.
+	field := reflect.ValueOf(&v).Elem().FieldByName("Note")
+	target, nullable := planFieldScan(field, true, nil)
+	if target != field.Addr().Interface() || len(nullable) != 0 {
+		t.Errorf("expected notnull field to scan into its own address unwrapped; got %T with %d nullable fields", target, len(nullable))
+	}
+}
+
+func TestColumnsUnaffectedByConvertTags(t *testing.T) {
+	var v convertTestType
+	e := "created_at, label, note"
+	if got := Columns(v); got != e {
+		t.Errorf("expected %q got %q", e, got)
+	}
+}
+
+type uintTestType struct {
+	Count uint `sql:"count"`
+}
+
+func TestScanUintField(t *testing.T) {
+	rows := testRows{}
+	rows.addValue("count", int64(42))
+
+	var v uintTestType
+	if err := Scan(&v, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if v.Count != 42 {
+		t.Errorf("expected Count 42 got %d", v.Count)
+	}
+}
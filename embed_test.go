@@ -0,0 +1,53 @@
+package sqlstruct
+
+import "testing"
+
+type Address struct {
+	Street string
+	City   string
+}
+
+type embedPerson struct {
+	Name string
+	Address
+}
+
+type prefixPerson struct {
+	Name    string
+	Address Address `sql:"addr,prefix"`
+}
+
+func TestColumnsEmbedded(t *testing.T) {
+	var v embedPerson
+	e := "address.city, address.street, name"
+	c := Columns(v)
+	if c != e {
+		t.Errorf("expected %q got %q", e, c)
+	}
+}
+
+func TestColumnsPrefixed(t *testing.T) {
+	var v prefixPerson
+	e := "addr_city, addr_street, name"
+	c := Columns(v)
+	if c != e {
+		t.Errorf("expected %q got %q", e, c)
+	}
+}
+
+func TestScanEmbedded(t *testing.T) {
+	rows := testRows{}
+	rows.addValue("name", "bob")
+	rows.addValue("address.street", "main st")
+	rows.addValue("address.city", "springfield")
+
+	var p embedPerson
+	if err := Scan(&p, rows); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := embedPerson{Name: "bob", Address: Address{Street: "main st", City: "springfield"}}
+	if p != e {
+		t.Errorf("expected %+v got %+v", e, p)
+	}
+}
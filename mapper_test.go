@@ -0,0 +1,61 @@
+package sqlstruct
+
+import (
+	"strings"
+	"testing"
+)
+
+type mapperTestType struct {
+	FieldA string
+	FieldB string `sql:"field_b"`
+}
+
+func upperMapper(name string) string {
+	return strings.ToUpper(name)
+}
+
+func TestColumnsWithMapper(t *testing.T) {
+	var v mapperTestType
+
+	e := "field_b, fielda"
+	c := ColumnsWithMapper(v, upperMapper)
+	if c != e {
+		t.Errorf("expected %q got %q", e, c)
+	}
+
+	// The default mapper should be unaffected by a per-call override.
+	e = "field_a, field_b"
+	c = Columns(v)
+	if c != e {
+		t.Errorf("expected %q got %q", e, c)
+	}
+}
+
+func TestScanWithMapper(t *testing.T) {
+	rows := testRows{}
+	rows.addValue("fielda", "a")
+	rows.addValue("field_b", "b")
+
+	var r mapperTestType
+	if err := ScanWithMapper(&r, rows, upperMapper); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := mapperTestType{"a", "b"}
+	if r != e {
+		t.Errorf("expected %q got %q", e, r)
+	}
+}
+
+func TestNameMapperOverride(t *testing.T) {
+	old := NameMapper
+	NameMapper = upperMapper
+	defer func() { NameMapper = old }()
+
+	var v mapperTestType
+	e := "field_b, fielda"
+	c := Columns(v)
+	if c != e {
+		t.Errorf("expected %q got %q", e, c)
+	}
+}
@@ -0,0 +1,221 @@
+package sqlstruct
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Dialect identifies the placeholder syntax a particular database driver expects
+// in its queries. It is used by Rebind to convert a query written with "?"
+// placeholders into the form the target driver understands.
+type Dialect int
+
+const (
+	// DialectQuestion uses a literal "?" for every placeholder, as used by the
+	// MySQL and SQLite drivers.
+	DialectQuestion Dialect = iota
+	// DialectDollar uses "$1", "$2", ... placeholders, as used by the Postgres
+	// driver.
+	DialectDollar
+	// DialectColon uses ":1", ":2", ... placeholders, as used by the Oracle
+	// driver.
+	DialectColon
+	// DialectAt uses "@p1", "@p2", ... placeholders, as used by the MSSQL
+	// driver.
+	DialectAt
+)
+
+// Named rewrites query, which may contain ":field_name" placeholders, into a
+// query using positional "?" placeholders along with the list of arguments to
+// pass to it. Values are sourced from arg, which must be a struct or a pointer
+// to a struct, using the same tag/snake-case resolution as Scan and Columns.
+func Named(query string, arg interface{}) (string, []interface{}, error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("sqlstruct: arg must be a struct or pointer to struct; got %T", arg)
+	}
+	fields := getFieldInfo(v.Type(), nil)
+
+	var out strings.Builder
+	var args []interface{}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		if c == '\'' || c == '"' {
+			j := skipQuoted(query, i)
+			out.WriteString(query[i:j])
+			i = j
+			continue
+		}
+
+		if c == ':' && i+1 < len(query) && query[i+1] == ':' {
+			// "::" is a Postgres type cast, not a bind prefix.
+			out.WriteString("::")
+			i += 2
+			continue
+		}
+
+		if c != ':' || i+1 >= len(query) || !isNameStart(query[i+1]) {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(query) && isNameChar(query[j]) {
+			j++
+		}
+		name := query[i+1 : j]
+
+		idx, ok := fields[strings.ToLower(name)]
+		if !ok {
+			return "", nil, fmt.Errorf("sqlstruct: no field found for :%s", name)
+		}
+		out.WriteByte('?')
+		args = append(args, v.FieldByIndex(idx).Interface())
+		i = j
+	}
+
+	return out.String(), args, nil
+}
+
+// skipQuoted returns the index just past the quoted literal or identifier
+// starting at query[start], which must be a single or double quote. A
+// doubled quote ('' or "") is treated as an escaped quote inside the literal
+// rather than its terminator, per standard SQL quoting, so colons inside
+// string literals (and quoted identifiers) are never mistaken for bind
+// prefixes.
+func skipQuoted(query string, start int) int {
+	quote := query[start]
+	i := start + 1
+	for i < len(query) {
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
+
+// In expands query, which must contain one "?" placeholder per argument, so
+// that any argument which is a slice becomes a "?, ?, ?" list matching the
+// length of the slice. This allows callers to write "WHERE id IN (?)" and pass
+// a single slice argument rather than building the placeholder list by hand.
+// Arguments which are not slices (including []byte) are passed through
+// unchanged. A "?" inside a quoted string literal or identifier is left alone
+// and does not consume an argument, the same as Named.
+func In(query string, args ...interface{}) (string, []interface{}, error) {
+	var out strings.Builder
+	var expanded []interface{}
+
+	argi := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		if c == '\'' || c == '"' {
+			j := skipQuoted(query, i)
+			out.WriteString(query[i:j])
+			i = j
+			continue
+		}
+
+		if c != '?' {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+		i++
+		if argi >= len(args) {
+			return "", nil, fmt.Errorf("sqlstruct: not enough arguments for query placeholders")
+		}
+		arg := args[argi]
+		argi++
+
+		v := reflect.ValueOf(arg)
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+			n := v.Len()
+			if n == 0 {
+				return "", nil, fmt.Errorf("sqlstruct: In: empty slice passed for argument %d", argi)
+			}
+			out.WriteString(strings.Repeat("?, ", n-1))
+			out.WriteByte('?')
+			for k := 0; k < n; k++ {
+				expanded = append(expanded, v.Index(k).Interface())
+			}
+			continue
+		}
+
+		out.WriteByte('?')
+		expanded = append(expanded, arg)
+	}
+
+	if argi != len(args) {
+		return "", nil, fmt.Errorf("sqlstruct: too many arguments for query placeholders")
+	}
+
+	return out.String(), expanded, nil
+}
+
+// Rebind converts a query written with "?" placeholders into the placeholder
+// syntax required by dialect. Queries written for DialectQuestion are returned
+// unchanged. A "?" inside a quoted string literal or identifier is left
+// alone, so it isn't mistaken for a placeholder; every "?" outside quotes,
+// including Postgres's jsonb "?"/"?|"/"?&" operators, is rewritten. Use this
+// after Named or In to target
+// Postgres, MSSQL or Oracle in addition to MySQL/SQLite.
+func Rebind(query string, dialect Dialect) string {
+	if dialect == DialectQuestion {
+		return query
+	}
+
+	var out strings.Builder
+	n := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		if c == '\'' || c == '"' {
+			j := skipQuoted(query, i)
+			out.WriteString(query[i:j])
+			i = j
+			continue
+		}
+
+		if c != '?' {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+		i++
+
+		n++
+		switch dialect {
+		case DialectDollar:
+			fmt.Fprintf(&out, "$%d", n)
+		case DialectColon:
+			fmt.Fprintf(&out, ":%d", n)
+		case DialectAt:
+			fmt.Fprintf(&out, "@p%d", n)
+		default:
+			out.WriteByte('?')
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,147 @@
+package sqlstruct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	v := testType{FieldA: "a", FieldC: "c", FieldF: 3}
+
+	q, args, err := Named("SELECT * FROM t WHERE field_a = :field_a AND field_f = :field_f", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "SELECT * FROM t WHERE field_a = ? AND field_f = ?"
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+
+	eargs := []interface{}{"a", 3}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected args %v got %v", eargs, args)
+	}
+}
+
+func TestNamedCast(t *testing.T) {
+	v := testType{FieldA: "a"}
+
+	q, args, err := Named("SELECT * FROM t WHERE field_a::text = :field_a", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "SELECT * FROM t WHERE field_a::text = ?"
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+
+	eargs := []interface{}{"a"}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected args %v got %v", eargs, args)
+	}
+}
+
+func TestNamedQuotedColon(t *testing.T) {
+	v := testType{FieldA: "a"}
+
+	q, args, err := Named("SELECT * FROM t WHERE field_a = :field_a AND note = 'time is 12:30'", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "SELECT * FROM t WHERE field_a = ? AND note = 'time is 12:30'"
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+
+	eargs := []interface{}{"a"}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected args %v got %v", eargs, args)
+	}
+}
+
+func TestNamedQuotedEscapedQuote(t *testing.T) {
+	v := testType{FieldA: "a"}
+
+	q, _, err := Named(`SELECT * FROM t WHERE field_a = :field_a AND note = 'it''s 12:30'`, v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := `SELECT * FROM t WHERE field_a = ? AND note = 'it''s 12:30'`
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+}
+
+func TestNamedUnknownField(t *testing.T) {
+	v := testType{}
+	if _, _, err := Named("SELECT * FROM t WHERE x = :no_such_field", v); err == nil {
+		t.Error("expected error for unknown field, got nil")
+	}
+}
+
+func TestIn(t *testing.T) {
+	q, args, err := In("SELECT * FROM t WHERE id IN (?) AND active = ?", []int{1, 2, 3}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "SELECT * FROM t WHERE id IN (?, ?, ?) AND active = ?"
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+
+	eargs := []interface{}{1, 2, 3, true}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected args %v got %v", eargs, args)
+	}
+}
+
+func TestInIgnoresQuestionMarkInQuotedLiteral(t *testing.T) {
+	q, args, err := In("SELECT * FROM t WHERE note = 'what?' AND id = ? AND active = ?", 42, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	e := "SELECT * FROM t WHERE note = 'what?' AND id = ? AND active = ?"
+	if q != e {
+		t.Errorf("expected query %q got %q", e, q)
+	}
+
+	eargs := []interface{}{42, true}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected args %v got %v", eargs, args)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	q := "SELECT * FROM t WHERE a = ? AND b = ?"
+
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{DialectQuestion, "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{DialectDollar, "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{DialectColon, "SELECT * FROM t WHERE a = :1 AND b = :2"},
+		{DialectAt, "SELECT * FROM t WHERE a = @p1 AND b = @p2"},
+	}
+
+	for _, c := range cases {
+		if got := Rebind(q, c.dialect); got != c.want {
+			t.Errorf("Rebind(%d): expected %q got %q", c.dialect, c.want, got)
+		}
+	}
+}
+
+func TestRebindIgnoresQuestionMarkInQuotedLiteral(t *testing.T) {
+	q := "SELECT * FROM t WHERE note = 'what?' AND a = ?"
+
+	e := "SELECT * FROM t WHERE note = 'what?' AND a = $1"
+	if got := Rebind(q, DialectDollar); got != e {
+		t.Errorf("expected %q got %q", e, got)
+	}
+}
@@ -0,0 +1,146 @@
+package sqlstruct
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sortedColumns returns typ's columns in the same order Columns(s) would
+// print them, i.e. sorted by column name.
+func sortedColumns(typ reflect.Type) []columnInfo {
+	cols := append([]columnInfo(nil), getColumnInfo(typ, nil)...)
+	sort.Slice(cols, func(i, j int) bool { return cols[i].name < cols[j].name })
+	return cols
+}
+
+// structValue dereferences s down to the struct it points to, or the struct
+// itself if s is not a pointer.
+func structValue(s interface{}) reflect.Value {
+	v := reflect.ValueOf(s)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	return v
+}
+
+// Values returns the values of s's fields, in the same column order as
+// Columns(s). s must be a struct, or a pointer to one.
+func Values(s interface{}) []interface{} {
+	v := structValue(s)
+	cols := sortedColumns(v.Type())
+
+	values := make([]interface{}, len(cols))
+	for i, c := range cols {
+		values[i] = v.FieldByIndex(c.index).Interface()
+	}
+	return values
+}
+
+// Placeholders returns a comma-separated list of placeholders, one per column
+// in Columns(s), written in the syntax dialect requires.
+func Placeholders(s interface{}, dialect Dialect) string {
+	v := structValue(s)
+	n := len(sortedColumns(v.Type()))
+	return Rebind(strings.TrimSuffix(strings.Repeat("?, ", n), ", "), dialect)
+}
+
+// InsertQuery builds an "INSERT INTO table (...) VALUES (...)" statement for
+// s using "?" placeholders, along with the matching argument list. Columns
+// tagged with the "pk" option are excluded, since primary keys are usually
+// generated by the database. Columns tagged with "omitempty" are excluded
+// when their value is the zero value, per isZero. InsertQuery returns an
+// error if every column ends up excluded, rather than handing the caller an
+// "INSERT INTO t () VALUES ()" that will fail at the database. Use Rebind to
+// target a driver that doesn't accept "?" placeholders.
+func InsertQuery(table string, s interface{}) (string, []interface{}, error) {
+	v := structValue(s)
+
+	var names []string
+	var args []interface{}
+	for _, c := range sortedColumns(v.Type()) {
+		if c.pk {
+			continue
+		}
+		fv := v.FieldByIndex(c.index)
+		if c.omitEmpty && isZero(fv) {
+			continue
+		}
+		names = append(names, c.name)
+		args = append(args, fv.Interface())
+	}
+
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("sqlstruct: InsertQuery: no columns to insert; refusing to build an INSERT with an empty column list")
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), placeholders)
+	return query, args, nil
+}
+
+// UpdateQuery builds an "UPDATE table SET ... WHERE ..." statement for s
+// using "?" placeholders, along with the matching argument list. Columns
+// tagged with "pk" are excluded from the SET list; columns tagged with
+// "omitempty" are excluded from it when their value is the zero value, per
+// isZero. whereCols names the columns to match in the WHERE clause; if empty,
+// the pk columns are used instead. UpdateQuery refuses to build a query with
+// no WHERE clause: it returns an error if whereCols is empty and s has no pk
+// column, or if whereCols names a column that doesn't exist, rather than
+// silently emitting an UPDATE that touches every row. It also returns an
+// error if every column ends up excluded from the SET list, rather than
+// handing the caller an "UPDATE t SET  WHERE ..." that will fail at the
+// database. Use Rebind to target a driver that doesn't accept "?"
+// placeholders.
+func UpdateQuery(table string, s interface{}, whereCols ...string) (string, []interface{}, error) {
+	v := structValue(s)
+	cols := sortedColumns(v.Type())
+
+	byName := make(map[string]columnInfo, len(cols))
+	for _, c := range cols {
+		byName[c.name] = c
+	}
+
+	if len(whereCols) == 0 {
+		for _, c := range cols {
+			if c.pk {
+				whereCols = append(whereCols, c.name)
+			}
+		}
+		if len(whereCols) == 0 {
+			return "", nil, fmt.Errorf("sqlstruct: UpdateQuery: no pk column and no whereCols given; refusing to build an UPDATE with no WHERE clause")
+		}
+	}
+
+	var sets []string
+	var args []interface{}
+	for _, c := range cols {
+		if c.pk {
+			continue
+		}
+		fv := v.FieldByIndex(c.index)
+		if c.omitEmpty && isZero(fv) {
+			continue
+		}
+		sets = append(sets, c.name+" = ?")
+		args = append(args, fv.Interface())
+	}
+
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("sqlstruct: UpdateQuery: no columns to set; refusing to build an UPDATE with an empty SET clause")
+	}
+
+	var wheres []string
+	for _, name := range whereCols {
+		c, ok := byName[name]
+		if !ok {
+			return "", nil, fmt.Errorf("sqlstruct: UpdateQuery: no column named %q", name)
+		}
+		wheres = append(wheres, name+" = ?")
+		args = append(args, v.FieldByIndex(c.index).Interface())
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), strings.Join(wheres, " AND "))
+	return query, args, nil
+}
@@ -0,0 +1,167 @@
+package sqlstruct
+
+import (
+	"reflect"
+	"testing"
+)
+
+type queryTestType struct {
+	ID    int    `sql:"id,pk"`
+	Name  string `sql:"name"`
+	Email string `sql:"email,omitempty"`
+}
+
+func TestValuesAndPlaceholders(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob", Email: "bob@example.com"}
+
+	e := []interface{}{"bob@example.com", 1, "bob"}
+	if got := Values(v); !reflect.DeepEqual(got, e) {
+		t.Errorf("expected %v got %v", e, got)
+	}
+
+	eph := "?, ?, ?"
+	if got := Placeholders(v, DialectQuestion); got != eph {
+		t.Errorf("expected %q got %q", eph, got)
+	}
+
+	eph = "$1, $2, $3"
+	if got := Placeholders(v, DialectDollar); got != eph {
+		t.Errorf("expected %q got %q", eph, got)
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob", Email: "bob@example.com"}
+
+	q, args, err := InsertQuery("users", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	eq := "INSERT INTO users (email, name) VALUES (?, ?)"
+	if q != eq {
+		t.Errorf("expected %q got %q", eq, q)
+	}
+
+	eargs := []interface{}{"bob@example.com", "bob"}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected %v got %v", eargs, args)
+	}
+}
+
+func TestInsertQueryOmitsEmpty(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob"}
+
+	q, args, err := InsertQuery("users", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	eq := "INSERT INTO users (name) VALUES (?)"
+	if q != eq {
+		t.Errorf("expected %q got %q", eq, q)
+	}
+
+	eargs := []interface{}{"bob"}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected %v got %v", eargs, args)
+	}
+}
+
+func TestUpdateQuery(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob", Email: "bob@example.com"}
+
+	q, args, err := UpdateQuery("users", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	eq := "UPDATE users SET email = ?, name = ? WHERE id = ?"
+	if q != eq {
+		t.Errorf("expected %q got %q", eq, q)
+	}
+
+	eargs := []interface{}{"bob@example.com", "bob", 1}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected %v got %v", eargs, args)
+	}
+}
+
+func TestUpdateQueryExplicitWhere(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob", Email: "bob@example.com"}
+
+	q, args, err := UpdateQuery("users", v, "name")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	eq := "UPDATE users SET email = ?, name = ? WHERE name = ?"
+	if q != eq {
+		t.Errorf("expected %q got %q", eq, q)
+	}
+
+	eargs := []interface{}{"bob@example.com", "bob", "bob"}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected %v got %v", eargs, args)
+	}
+}
+
+type noWhereTestType struct {
+	Name string `sql:"name"`
+}
+
+func TestUpdateQueryNoPkNoWhereColsErrors(t *testing.T) {
+	v := noWhereTestType{Name: "bob"}
+
+	if _, _, err := UpdateQuery("users", v); err == nil {
+		t.Error("expected error when there is no pk column and no whereCols, got nil")
+	}
+}
+
+func TestUpdateQueryUnknownWhereColErrors(t *testing.T) {
+	v := queryTestType{ID: 1, Name: "bob", Email: "bob@example.com"}
+
+	if _, _, err := UpdateQuery("users", v, "no_such_column"); err == nil {
+		t.Error("expected error for unknown whereCols entry, got nil")
+	}
+}
+
+type uintOmitEmptyTestType struct {
+	ID    int  `sql:"id,pk"`
+	Count uint `sql:"count,omitempty"`
+}
+
+func TestOmitEmptyUintColumnDoesNotPanic(t *testing.T) {
+	v := uintOmitEmptyTestType{ID: 1, Count: 5}
+
+	q, args, err := InsertQuery("users", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	eq := "INSERT INTO users (count) VALUES (?)"
+	if q != eq {
+		t.Errorf("expected %q got %q", eq, q)
+	}
+
+	eargs := []interface{}{uint(5)}
+	if !reflect.DeepEqual(args, eargs) {
+		t.Errorf("expected %v got %v", eargs, args)
+	}
+}
+
+func TestInsertQueryAllColumnsOmittedErrors(t *testing.T) {
+	v := uintOmitEmptyTestType{ID: 1, Count: 0}
+
+	if _, _, err := InsertQuery("users", v); err == nil {
+		t.Error("expected error when every column is omitted, got nil")
+	}
+}
+
+func TestUpdateQueryAllColumnsOmittedErrors(t *testing.T) {
+	v := uintOmitEmptyTestType{ID: 1, Count: 0}
+
+	if _, _, err := UpdateQuery("users", v); err == nil {
+		t.Error("expected error when every SET column is omitted, got nil")
+	}
+}
@@ -7,10 +7,13 @@ Package sqlstruct provides some convenience functions for using structs with
 the Go standard library's database/sql package.
 
 The package matches struct field names to SQL query column names. Field names are
-automatically converted to snake case. A field can also specify a matching column
-with "sql" tag, if it's different from field name.  Unexported fields or fields marked
-with `sql:"-"` are ignored, just like with "encoding/json" package. Anonymous fields
-are currently ignored as well.
+automatically converted using NameMapper, which defaults to snake case. A field can
+also specify a matching column with "sql" tag, if it's different from field name.
+Unexported fields or fields marked with `sql:"-"` are ignored, just like with
+"encoding/json" package. Embedded (anonymous) struct fields are recursed into,
+contributing dotted column names such as "address.street"; a named struct field
+tagged with the "prefix" option (e.g. `sql:"addr,prefix"`) is recursed into the
+same way but joins with "_" instead, e.g. "addr_street".
 
 For example:
 
@@ -44,18 +47,56 @@ import (
 	"sync"
 )
 
-// A cache of fieldInfos to save reflecting every time. Inspried by encoding/xml
-var finfos map[reflect.Type]fieldInfo
+// A cache of columnInfo slices to save reflecting every time. Inspried by
+// encoding/xml. It is keyed on the struct type and the identity of the mapper
+// used to build it, since the same type can map to different columns under
+// different mappers.
+var finfos map[finfoKey][]columnInfo
 var finfoLock sync.RWMutex
 
 // tagName is the name of the tag to use on struct fields
 const tagName = "sql"
 
-// fieldInfo is a mapping of field tag values to their indices
-type fieldInfo map[string]int
+// fieldInfo is a mapping of column names to the index chain (suitable for
+// reflect.Value.FieldByIndex) of the field they come from. Columns sourced
+// from embedded or prefixed struct fields have more than one entry in their
+// index chain.
+type fieldInfo map[string][]int
+
+// finfoKey identifies a cached fieldInfo by the struct type it was built from
+// and the mapper used to derive untagged field names. The mapper is tracked
+// by its func value's code pointer (reflect.Value.Pointer), which is stable
+// for a given function literal or named func but distinct for every closure
+// built from the same literal. Because finfos/scanPlans are never evicted,
+// passing a freshly-built closure as the mapper on every call (rather than a
+// mapper value reused across calls) grows these caches for the life of the
+// process; see Mapper.
+type finfoKey struct {
+	typ    reflect.Type
+	mapper uintptr
+}
+
+// Mapper maps a struct field name to a column name. It is used in place of
+// NameMapper for a single call via ScanWithMapper/ColumnsWithMapper.
+//
+// Mappers are cached by (type, mapper) in a map that is never evicted, so
+// callers should pass a mapper value that is reused across calls (a
+// package-level func, or a closure built once and kept around) rather than
+// building a new closure per call/request/row. A mapper rebuilt on every call
+// still behaves correctly, but each distinct closure value grows the cache
+// for the life of the process.
+type Mapper func(string) string
+
+// NameMapper is the function used to convert an untagged struct field name
+// into a column name. It defaults to snake_case, matching this package's
+// original behavior, but can be overridden to interop with databases whose
+// naming conventions differ (CamelCase, ALL_CAPS, dotted paths, etc). Changing
+// it affects every subsequent call to Scan and Columns; use ScanWithMapper or
+// ColumnsWithMapper to override it for a single call instead.
+var NameMapper Mapper = snakeCasedName
 
 func init() {
-	finfos = make(map[reflect.Type]fieldInfo)
+	finfos = make(map[finfoKey][]columnInfo)
 }
 
 // Rows defines the interface of types that are scannable with the Scan function.
@@ -65,9 +106,12 @@ type Rows interface {
 	Columns() ([]string, error)
 }
 
+// nullableField pairs a destination field with the assign function that
+// copies its scanned value out of the scan target built for it by
+// planFieldScan, once rows.Scan has populated that target.
 type nullableField struct {
-	field reflect.Value
-	value interface{}
+	field  reflect.Value
+	assign func(reflect.Value)
 }
 
 func snakeCasedName(name string) string {
@@ -89,57 +133,212 @@ func snakeCasedName(name string) string {
 	return string(newstr)
 }
 
-// getFieldInfo creates a fieldInfo for the provided type. Fields that are not tagged
-// with the "sql" tag and unexported fields are not included.
-func getFieldInfo(typ reflect.Type) fieldInfo {
+// parseTag splits a "sql" tag into its column name and its comma-separated
+// options, following the convention used by "encoding/json".
+func parseTag(tag string) (name string, opts map[string]bool) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if len(parts) > 1 {
+		opts = make(map[string]bool, len(parts)-1)
+		for _, opt := range parts[1:] {
+			opts[strings.TrimSpace(opt)] = true
+		}
+	}
+	return name, opts
+}
+
+// columnInfo describes one flattened column derived from a struct field: its
+// column name, its field index chain (suitable for reflect.Value.FieldByIndex),
+// and the "pk"/"omitempty" tag options used by InsertQuery/UpdateQuery.
+type columnInfo struct {
+	name      string
+	index     []int
+	pk        bool
+	omitEmpty bool
+	notNull   bool
+}
+
+// getFieldInfo creates a fieldInfo for the provided type using mapper to derive
+// column names for untagged fields. Unexported fields and fields tagged with
+// "-" are not included.
+func getFieldInfo(typ reflect.Type, mapper Mapper) fieldInfo {
+	cols := getColumnInfo(typ, mapper)
+
+	finfo := make(fieldInfo, len(cols))
+	for _, c := range cols {
+		finfo[c.name] = c.index
+	}
+	return finfo
+}
+
+// getColumnInfo creates the []columnInfo for the provided type using mapper to
+// derive column names for untagged fields. The result is cached per
+// (type, mapper) pair.
+func getColumnInfo(typ reflect.Type, mapper Mapper) []columnInfo {
+	if mapper == nil {
+		mapper = NameMapper
+	}
+	key := finfoKey{typ: typ, mapper: reflect.ValueOf(mapper).Pointer()}
+
 	finfoLock.RLock()
-	finfo, ok := finfos[typ]
+	cols, ok := finfos[key]
 	finfoLock.RUnlock()
 	if ok {
-		return finfo
+		return cols
 	}
 
-	finfo = make(fieldInfo)
+	cols = collectColumns(typ, mapper)
+
+	finfoLock.Lock()
+	finfos[key] = cols
+	finfoLock.Unlock()
+
+	return cols
+}
+
+// collectColumns walks the fields of typ, recursing into anonymous
+// (embedded) struct fields and into named struct fields tagged with the
+// "prefix" option, and returns the flattened list of columns. Untagged
+// embedded fields are joined to their parent with ".", e.g. an embedded
+// Address field contributes "address.street"; a field whose tag names a
+// column, such as `sql:"addr,prefix"`, instead joins with "_", contributing
+// "addr_street".
+func collectColumns(typ reflect.Type, mapper Mapper) []columnInfo {
+	var cols []columnInfo
 
 	n := typ.NumField()
 	for i := 0; i < n; i++ {
 		f := typ.Field(i)
-		tag := f.Tag.Get(tagName)
+		name, opts := parseTag(f.Tag.Get(tagName))
+
+		// Skip unexported fields and fields marked with "-"
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct && (f.Anonymous || opts["prefix"]) {
+			prefix, sep := strings.ToLower(name), "_"
+			if prefix == "" {
+				prefix, sep = strings.ToLower(mapper(f.Name)), "."
+			}
 
-		// Skip unexported fields, fields marked with "-" or anonymous fields
-		if f.PkgPath != "" || tag == "-" || f.Anonymous {
+			for _, sub := range collectColumns(f.Type, mapper) {
+				cols = append(cols, columnInfo{
+					name:      prefix + sep + sub.name,
+					index:     append([]int{i}, sub.index...),
+					pk:        sub.pk,
+					omitEmpty: sub.omitEmpty,
+					notNull:   sub.notNull,
+				})
+			}
 			continue
 		}
 
-		// Use field name for untagged fields
-		if tag == "" {
-			tag = snakeCasedName(f.Name)
+		// Other anonymous fields (non-struct) are ignored, as before.
+		if f.Anonymous {
+			continue
+		}
+
+		// Use the mapper for untagged fields. The result is lowercased, like
+		// an explicit tag, so that Scan's case-insensitive column matching
+		// keeps working regardless of what casing convention the mapper uses.
+		if name == "" {
+			name = strings.ToLower(mapper(f.Name))
 		} else {
-			tag = strings.ToLower(tag)
+			name = strings.ToLower(name)
 		}
 
-		finfo[tag] = i
+		cols = append(cols, columnInfo{
+			name:      name,
+			index:     []int{i},
+			pk:        opts["pk"],
+			omitEmpty: opts["omitempty"],
+			notNull:   opts["notnull"],
+		})
 	}
 
-	finfoLock.Lock()
-	finfos[typ] = finfo
-	finfoLock.Unlock()
+	return cols
+}
 
-	return finfo
+// scanPlanKey identifies a cached scanPlan by destination type, mapper identity
+// and the exact set/order of columns it was built for.
+type scanPlanKey struct {
+	typ     reflect.Type
+	mapper  uintptr
+	columns string
+}
+
+// scanField is the resolved plan for a single result column: the field it
+// should be scanned into, or a nil index if the column has no matching field
+// and should be discarded.
+type scanField struct {
+	index   []int
+	notNull bool
+}
+
+// scanPlans is a cache of the per-column scanFields resolved for a given
+// (type, mapper, columns) combination, in column order.
+var scanPlans map[scanPlanKey][]scanField
+var scanPlanLock sync.RWMutex
+
+func init() {
+	scanPlans = make(map[scanPlanKey][]scanField)
+}
+
+// getScanPlan resolves cols against typ's columns once per distinct
+// (type, mapper, columns) combination, so repeated Scan calls against the
+// same query shape don't re-lowercase and re-lookup every column on every row.
+func getScanPlan(typ reflect.Type, mapper Mapper, cols []string) []scanField {
+	if mapper == nil {
+		mapper = NameMapper
+	}
+	key := scanPlanKey{typ: typ, mapper: reflect.ValueOf(mapper).Pointer(), columns: strings.Join(cols, "\x00")}
+
+	scanPlanLock.RLock()
+	plan, ok := scanPlans[key]
+	scanPlanLock.RUnlock()
+	if ok {
+		return plan
+	}
+
+	byName := make(map[string]columnInfo, len(cols))
+	for _, c := range getColumnInfo(typ, mapper) {
+		byName[c.name] = c
+	}
+
+	plan = make([]scanField, len(cols))
+	for i, name := range cols {
+		if c, ok := byName[strings.ToLower(name)]; ok {
+			plan[i] = scanField{index: c.index, notNull: c.notNull}
+		}
+	}
+
+	scanPlanLock.Lock()
+	scanPlans[key] = plan
+	scanPlanLock.Unlock()
+
+	return plan
 }
 
 // Scan scans the next row from rows in to a struct pointed to by dest. The struct type
 // should have exported fields tagged with the "sql" tag. Columns from row which are not
 // mapped to any struct fields are ignored. Struct fields which have no matching column
-// in the result set are left unchanged.
+// in the result set are left unchanged. Untagged fields are mapped to columns using
+// NameMapper.
 func Scan(dest interface{}, rows Rows) error {
+	return ScanWithMapper(dest, rows, nil)
+}
+
+// ScanWithMapper behaves like Scan but uses mapper, rather than NameMapper, to derive
+// column names for untagged fields. A nil mapper is equivalent to calling Scan. See
+// the Mapper docs for the caching caveat around passing a fresh closure per call.
+func ScanWithMapper(dest interface{}, rows Rows, mapper Mapper) error {
 	destv := reflect.ValueOf(dest)
 	typ := destv.Type()
 
 	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
 		panic(fmt.Errorf("dest must be pointer to struct; got %T", destv))
 	}
-	fieldInfo := getFieldInfo(typ.Elem())
 
 	elem := destv.Elem()
 	var values []interface{}
@@ -148,34 +347,19 @@ func Scan(dest interface{}, rows Rows) error {
 	if err != nil {
 		return err
 	}
+	plan := getScanPlan(typ.Elem(), mapper, cols)
 
 	var nullableFields []nullableField
 
-	for _, name := range cols {
-		idx, ok := fieldInfo[strings.ToLower(name)]
+	for _, sf := range plan {
 		var v interface{}
-		if !ok {
+		switch {
+		case sf.index == nil:
 			// There is no field mapped to this column so we discard it
 			v = &sql.RawBytes{}
-		} else {
-			//Substitute nullable fields to transparently support them
-			switch elem.Field(idx).Kind() {
-			case reflect.Bool:
-				v = &sql.NullBool{}
-				nullableFields = append(nullableFields, nullableField{field: elem.Field(idx), value: v})
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				v = &sql.NullInt64{}
-				nullableFields = append(nullableFields, nullableField{field: elem.Field(idx), value: v})
-			case reflect.Float32, reflect.Float64:
-				v = &sql.NullFloat64{}
-				nullableFields = append(nullableFields, nullableField{field: elem.Field(idx), value: v})
-			case reflect.String:
-				v = &sql.NullString{}
-				nullableFields = append(nullableFields, nullableField{field: elem.Field(idx), value: v})
-			default:
-				v = elem.Field(idx).Addr().Interface()
-			}
+		default:
+			field := elem.FieldByIndex(sf.index)
+			v, nullableFields = planFieldScan(field, sf.notNull, nullableFields)
 		}
 		values = append(values, v)
 	}
@@ -184,47 +368,68 @@ func Scan(dest interface{}, rows Rows) error {
 		return err
 	}
 
-	for _, nullableField := range nullableFields {
-		switch nullableField.value.(type) {
-		case *sql.NullBool:
-			value := *nullableField.value.(*sql.NullBool)
-			if value.Valid {
-				nullableField.field.SetBool(value.Bool)
-			} else {
-				nullableField.field.SetBool(false)
-			}
-		case *sql.NullInt64:
-			value := *nullableField.value.(*sql.NullInt64)
-			if value.Valid {
-				nullableField.field.SetInt(value.Int64)
-			} else {
-				nullableField.field.SetInt(0)
-			}
-		case *sql.NullFloat64:
-			value := *nullableField.value.(*sql.NullFloat64)
-			if value.Valid {
-				nullableField.field.SetFloat(value.Float64)
-			} else {
-				nullableField.field.SetFloat(0)
-			}
-		case *sql.NullString:
-			value := *nullableField.value.(*sql.NullString)
-			if value.Valid {
-				nullableField.field.SetString(value.String)
-			} else {
-				nullableField.field.SetString("")
-			}
-		}
+	for _, nf := range nullableFields {
+		nf.assign(nf.field)
 	}
 
 	return nil
 }
 
+// planFieldScan picks what to scan field's column value into: the field's own
+// address if it opted out of null handling (via the "notnull" tag option) or
+// already implements sql.Scanner; a registered converter's scan target if one
+// is registered for field's exact type; or, failing that, one of the built-in
+// nullable wrappers for bool/int/float/string kinds. It returns the scan
+// target and the (possibly extended) list of fields to assign once rows.Scan
+// has populated their targets.
+func planFieldScan(field reflect.Value, notNull bool, nullableFields []nullableField) (interface{}, []nullableField) {
+	if notNull {
+		return field.Addr().Interface(), nullableFields
+	}
+
+	if field.CanAddr() && field.Addr().Type().Implements(scannerType) {
+		return field.Addr().Interface(), nullableFields
+	}
+
+	if factory, ok := getConverter(field.Type()); ok {
+		target, assign := factory()
+		return target, append(nullableFields, nullableField{field: field, assign: assign})
+	}
+
+	//Substitute nullable fields to transparently support them
+	var target interface{}
+	var assign func(reflect.Value)
+	switch field.Kind() {
+	case reflect.Bool:
+		target, assign = nullBoolConverter()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		target, assign = nullInt64Converter()
+	case reflect.Float32, reflect.Float64:
+		target, assign = nullFloat64Converter()
+	case reflect.String:
+		target, assign = nullStringConverter()
+	default:
+		return field.Addr().Interface(), nullableFields
+	}
+
+	return target, append(nullableFields, nullableField{field: field, assign: assign})
+}
+
 // Columns returns a string containing a sorted, comma-separated list of column names as defined
 // by the type s. s must be a struct that has exported fields tagged with the "sql" tag.
+// Untagged fields are mapped to columns using NameMapper.
 func Columns(s interface{}) string {
+	return ColumnsWithMapper(s, nil)
+}
+
+// ColumnsWithMapper behaves like Columns but uses mapper, rather than NameMapper, to
+// derive column names for untagged fields. A nil mapper is equivalent to calling
+// Columns. See the Mapper docs for the caching caveat around passing a fresh closure
+// per call.
+func ColumnsWithMapper(s interface{}, mapper Mapper) string {
 	v := reflect.ValueOf(s)
-	fields := getFieldInfo(v.Type())
+	fields := getFieldInfo(v.Type(), mapper)
 
 	names := make([]string, 0, len(fields))
 	for f := range fields {
@@ -241,19 +446,19 @@ func NullValue(value interface{}) interface{} {
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if v.Int() == 0 {
+		if isZero(v) {
 			return sql.NullInt64{Int64: 0, Valid: false}
 		} else {
 			return value
 		}
 	case reflect.Float32, reflect.Float64:
-		if v.Float() == 0 {
+		if isZero(v) {
 			return sql.NullFloat64{Float64: 0, Valid: false}
 		} else {
 			return value
 		}
 	case reflect.String:
-		if strings.TrimSpace(value.(string)) == "" {
+		if isZero(v) {
 			return sql.NullString{String: "", Valid: false}
 		} else {
 			return value
@@ -262,3 +467,22 @@ func NullValue(value interface{}) interface{} {
 
 	return value
 }
+
+// isZero reports whether v holds a value this package considers "empty":
+// zero for numeric kinds, and blank (after trimming whitespace) for strings.
+// Other kinds fall back to reflect.Value.IsZero. It backs both NullValue and
+// the "omitempty" tag option used by InsertQuery/UpdateQuery.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	default:
+		return v.IsZero()
+	}
+}
@@ -38,8 +38,8 @@ func (r testRows) Scan(dest ...interface{}) error {
 			(*dest[i].(*sql.NullBool)).Bool = r.values[i].(bool)
 			(*dest[i].(*sql.NullBool)).Valid = true
 		case *sql.NullInt64:
-			dest[i] = nil
-			(*dest[i].(*sql.NullInt64)).Valid = false
+			(*dest[i].(*sql.NullInt64)).Int64 = r.values[i].(int64)
+			(*dest[i].(*sql.NullInt64)).Valid = true
 		case *sql.NullFloat64:
 			(*dest[i].(*sql.NullFloat64)).Float64 = r.values[i].(float64)
 			(*dest[i].(*sql.NullFloat64)).Valid = true
@@ -47,7 +47,13 @@ func (r testRows) Scan(dest ...interface{}) error {
 			(*dest[i].(*sql.NullString)).String = r.values[i].(string)
 			(*dest[i].(*sql.NullString)).Valid = true
 		default:
-			// Do nothing. We assume the tests only use strings here
+			// Mimic database/sql: if dest implements sql.Scanner, let it scan
+			// the raw value itself.
+			if scanner, ok := dest[i].(sql.Scanner); ok {
+				if err := scanner.Scan(r.values[i]); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil